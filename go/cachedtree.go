@@ -0,0 +1,127 @@
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// CachedTree proves membership over very large inputs by remembering only
+// the roots of fixed-size subtrees rather than every underlying leaf.
+// Each pushed subtree root covers 2^depth leaves, so the tree holds
+// O(N / 2^depth) hashes in memory regardless of N. Prove stitches a
+// full-resolution proof computed over just the subtree containing the
+// target index (via BuildReaderProof) together with the sibling path
+// derived from these cached upper-layer roots.
+type CachedTree struct {
+	depth     uint
+	hasher    Hasher
+	subRoots  [][]byte
+	subLeaves []uint64 // subLeaves[i] = number of leaves actually hashed into subRoots[i]; equal to 2^depth except possibly for the last subtree
+}
+
+// NewCachedTree creates a CachedTree whose subtree roots each cover
+// 2^depth leaves. A nil hasher defaults to Sha256Hasher.
+func NewCachedTree(depth uint, hasher Hasher) *CachedTree {
+	if hasher == nil {
+		hasher = Sha256Hasher{}
+	}
+	return &CachedTree{depth: depth, hasher: hasher}
+}
+
+// PushSubTree appends the next subtree root, in leaf order. numLeaves is
+// the number of leaves actually hashed into root; it is 2^depth for every
+// subtree except possibly the last, which may be partial.
+func (c *CachedTree) PushSubTree(root []byte, numLeaves uint64) {
+	c.subRoots = append(c.subRoots, root)
+	c.subLeaves = append(c.subLeaves, numLeaves)
+}
+
+// topTreeProof builds the Merkle path from the subtree root at topIndex up
+// to the root of the tree-of-subtree-roots. Unlike NewMerkleTree it does
+// not pad with empty leaves: a trailing subtree root without a sibling is
+// simply promoted to the next layer unchanged.
+func (c *CachedTree) topTreeProof(topIndex uint64) (root []byte, siblings [][]byte, directions []bool, err error) {
+	if len(c.subRoots) == 0 {
+		return nil, nil, nil, fmt.Errorf("no subtrees pushed")
+	}
+	if int(topIndex) >= len(c.subRoots) {
+		return nil, nil, nil, fmt.Errorf("index out of bounds")
+	}
+
+	level := make([][]byte, len(c.subRoots))
+	copy(level, c.subRoots)
+	idx := topIndex
+
+	for len(level) > 1 {
+		var next [][]byte
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				// Odd one out: promote it unchanged to the next layer.
+				if idx == uint64(i) {
+					idx = uint64(len(next))
+				}
+				next = append(next, level[i])
+				continue
+			}
+
+			left, right := level[i], level[i+1]
+			if idx == uint64(i) {
+				siblings = append(siblings, right)
+				directions = append(directions, false)
+				idx = uint64(len(next))
+			} else if idx == uint64(i+1) {
+				siblings = append(siblings, left)
+				directions = append(directions, true)
+				idx = uint64(len(next))
+			}
+			next = append(next, c.hasher.HashNode(left, right))
+		}
+		level = next
+	}
+
+	return level[0], siblings, directions, nil
+}
+
+// Prove produces a proof of inclusion for the leaf at proofIndex against
+// the full root. subTreeData must be a reader over the raw segments of
+// the subtree containing proofIndex (i.e. the same data that was hashed
+// to produce the cached root at that position); Prove recomputes that
+// subtree's root to make sure it matches what was cached before stitching
+// on the cached upper layers.
+func (c *CachedTree) Prove(proofIndex uint64, subTreeData io.Reader, segmentSize int) (root []byte, proof MerkleProof, numLeaves uint64, err error) {
+	subTreeSize := uint64(1) << c.depth
+	topIndex := proofIndex / subTreeSize
+	localIndex := proofIndex % subTreeSize
+
+	if int(topIndex) >= len(c.subRoots) {
+		return nil, MerkleProof{}, 0, fmt.Errorf("index out of bounds")
+	}
+
+	subRoot, subProof, subNumLeaves, err := BuildReaderProof(subTreeData, segmentSize, localIndex, c.hasher)
+	if err != nil {
+		return nil, MerkleProof{}, 0, err
+	}
+	if !bytes.Equal(subRoot, c.subRoots[topIndex]) {
+		return nil, MerkleProof{}, 0, fmt.Errorf("subtree data does not match cached root")
+	}
+
+	topRoot, topSiblings, topDirections, err := c.topTreeProof(topIndex)
+	if err != nil {
+		return nil, MerkleProof{}, 0, err
+	}
+
+	proof = MerkleProof{
+		hElement:   subProof.hElement,
+		siblings:   append(append([][]byte{}, subProof.siblings...), topSiblings...),
+		directions: append(append([]bool{}, subProof.directions...), topDirections...),
+	}
+	numLeaves = subNumLeaves
+	for i, n := range c.subLeaves {
+		if uint64(i) != topIndex {
+			numLeaves += n
+		}
+	}
+
+	return topRoot, proof, numLeaves, nil
+}
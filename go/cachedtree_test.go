@@ -0,0 +1,93 @@
+package merkletree
+
+import (
+	"strings"
+	"testing"
+)
+
+// buildSubTreeRoot hashes a subtree's segments the same way BuildReaderProof
+// would, without needing a proof, so tests can populate a CachedTree.
+func buildSubTreeRoot(t *testing.T, data string, segmentSize int) []byte {
+	t.Helper()
+	return buildSubTreeRootWithHasher(t, data, segmentSize, nil)
+}
+
+func buildSubTreeRootWithHasher(t *testing.T, data string, segmentSize int, hasher Hasher) []byte {
+	t.Helper()
+	root, _, _, err := BuildReaderProof(strings.NewReader(data), segmentSize, 0, hasher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestCachedTreeProveAcrossMultipleSubTrees(t *testing.T) {
+	const depth = 2 // each subtree covers 2^2 = 4 leaves
+	segmentSize := 1
+
+	subTreeData := []string{"abcd", "efgh", "ijk"} // last subtree has 3 leaves
+	wantNumLeaves := uint64(len("abcd") + len("efgh") + len("ijk"))
+	ct := NewCachedTree(depth, Sha256Hasher{})
+	for _, data := range subTreeData {
+		ct.PushSubTree(buildSubTreeRoot(t, data, segmentSize), uint64(len(data)))
+	}
+
+	for subIdx, data := range subTreeData {
+		for local := 0; local < len(data); local++ {
+			proofIndex := uint64(subIdx)*(uint64(1)<<depth) + uint64(local)
+			root, proof, numLeaves, err := ct.Prove(proofIndex, strings.NewReader(data), segmentSize)
+			if err != nil {
+				t.Fatalf("Prove(%d): %v", proofIndex, err)
+			}
+			if !VerifyProof(root, proof, Sha256Hasher{}) {
+				t.Errorf("invalid proof for leaf %d", proofIndex)
+			}
+			if numLeaves != wantNumLeaves {
+				t.Errorf("Prove(%d) numLeaves = %d, want %d", proofIndex, numLeaves, wantNumLeaves)
+			}
+		}
+	}
+}
+
+func TestCachedTreeProveWithNonDefaultHasher(t *testing.T) {
+	const depth = 2
+	segmentSize := 1
+	hasher := RFC6962Hasher{}
+
+	data := "abcd"
+	ct := NewCachedTree(depth, hasher)
+	ct.PushSubTree(buildSubTreeRootWithHasher(t, data, segmentSize, hasher), uint64(len(data)))
+
+	root, proof, numLeaves, err := ct.Prove(1, strings.NewReader(data), segmentSize)
+	if err != nil {
+		t.Fatalf("Prove: %v", err)
+	}
+	if numLeaves != uint64(len(data)) {
+		t.Errorf("numLeaves = %d, want %d", numLeaves, len(data))
+	}
+	if !VerifyProof(root, proof, hasher) {
+		t.Error("invalid proof when CachedTree uses a non-default hasher")
+	}
+}
+
+func TestCachedTreeProveMismatchedSubTreeData(t *testing.T) {
+	const depth = 1
+	ct := NewCachedTree(depth, Sha256Hasher{})
+	ct.PushSubTree(buildSubTreeRoot(t, "ab", 1), 2)
+
+	_, _, _, err := ct.Prove(0, strings.NewReader("zz"), 1)
+	if err == nil {
+		t.Error("expected an error when subtree data does not match the cached root, but got none")
+	}
+}
+
+func TestCachedTreeProveIndexOutOfBounds(t *testing.T) {
+	const depth = 1
+	ct := NewCachedTree(depth, Sha256Hasher{})
+	ct.PushSubTree(buildSubTreeRoot(t, "ab", 1), 2)
+
+	_, _, _, err := ct.Prove(10, strings.NewReader("ab"), 1)
+	if err == nil {
+		t.Error("expected an error for an out of bounds proofIndex, but got none")
+	}
+}
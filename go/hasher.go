@@ -0,0 +1,50 @@
+package merkletree
+
+import "crypto/sha256"
+
+// Hasher computes leaf and internal node hashes for a MerkleTree. Plugging
+// in a different Hasher lets callers pick the hash function (and any
+// domain-separation scheme) the tree is built with, instead of being
+// locked into a single hardcoded algorithm.
+type Hasher interface {
+	HashLeaf(data []byte) []byte
+	HashNode(left, right []byte) []byte
+}
+
+// Sha256Hasher hashes leaves and nodes with plain SHA-256 and no domain
+// separation between the two, matching the tree's original behavior.
+type Sha256Hasher struct{}
+
+func (Sha256Hasher) HashLeaf(data []byte) []byte {
+	h := sha256.Sum256(data)
+	return h[:]
+}
+
+func (Sha256Hasher) HashNode(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// RFC6962Hasher implements the Certificate Transparency leaf/node hashing
+// scheme from RFC 6962 section 2.1: leaves are hashed as H(0x00 || data)
+// and internal nodes as H(0x01 || left || right). The domain-separating
+// prefix stops an attacker from presenting an internal node as if it were
+// a leaf (or vice versa) to forge a proof.
+type RFC6962Hasher struct{}
+
+func (RFC6962Hasher) HashLeaf(data []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x00})
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func (RFC6962Hasher) HashNode(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x01})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
@@ -0,0 +1,54 @@
+package merkletree
+
+import (
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// LevelDBStorage is a Storage backed by an on-disk LevelDB database, for
+// SparseMerkleTrees that need to persist across process restarts.
+type LevelDBStorage struct {
+	db *leveldb.DB
+}
+
+// NewLevelDBStorage opens (creating if necessary) a LevelDB database at path.
+func NewLevelDBStorage(path string) (*LevelDBStorage, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &LevelDBStorage{db: db}, nil
+}
+
+func (s *LevelDBStorage) Get(key []byte) ([]byte, error) {
+	v, err := s.db.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return nil, ErrNotFound
+	}
+	return v, err
+}
+
+func (s *LevelDBStorage) Put(key, value []byte) error {
+	return s.db.Put(key, value, nil)
+}
+
+func (s *LevelDBStorage) NewBatch() Batch {
+	return &levelDBBatch{db: s.db, batch: new(leveldb.Batch)}
+}
+
+// Close releases the underlying LevelDB database handle.
+func (s *LevelDBStorage) Close() error {
+	return s.db.Close()
+}
+
+type levelDBBatch struct {
+	db    *leveldb.DB
+	batch *leveldb.Batch
+}
+
+func (b *levelDBBatch) Put(key, value []byte) {
+	b.batch.Put(key, value)
+}
+
+func (b *levelDBBatch) Commit() error {
+	return b.db.Write(b.batch, nil)
+}
@@ -0,0 +1,90 @@
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLevelDBStorageGetPutRoundTrip(t *testing.T) {
+	s, err := NewLevelDBStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLevelDBStorage: %v", err)
+	}
+	defer s.Close()
+
+	key, value := []byte("alice"), []byte("100")
+	if err := s.Put(key, value); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	got, err := s.Get(key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !bytes.Equal(got, value) {
+		t.Errorf("Get = %s, want %s", got, value)
+	}
+
+	if _, err := s.Get([]byte("unknown")); err != ErrNotFound {
+		t.Errorf("Get(unknown) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestLevelDBStorageBatchCommit(t *testing.T) {
+	s, err := NewLevelDBStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLevelDBStorage: %v", err)
+	}
+	defer s.Close()
+
+	batch := s.NewBatch()
+	batch.Put([]byte("alice"), []byte("100"))
+	batch.Put([]byte("bob"), []byte("200"))
+
+	if _, err := s.Get([]byte("alice")); err != ErrNotFound {
+		t.Errorf("Get(alice) before Commit error = %v, want ErrNotFound", err)
+	}
+
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	for key, want := range map[string]string{"alice": "100", "bob": "200"} {
+		got, err := s.Get([]byte(key))
+		if err != nil {
+			t.Fatalf("Get(%s): %v", key, err)
+		}
+		if string(got) != want {
+			t.Errorf("Get(%s) = %s, want %s", key, got, want)
+		}
+	}
+}
+
+func TestLevelDBStorageReopenAtPath(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := NewLevelDBStorage(dir)
+	if err != nil {
+		t.Fatalf("NewLevelDBStorage: %v", err)
+	}
+	if err := s.Put([]byte("alice"), []byte("100")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	reopened, err := NewLevelDBStorage(dir)
+	if err != nil {
+		t.Fatalf("NewLevelDBStorage (reopen): %v", err)
+	}
+	defer reopened.Close()
+
+	got, err := reopened.Get([]byte("alice"))
+	if err != nil {
+		t.Fatalf("Get(alice) after reopen: %v", err)
+	}
+	if string(got) != "100" {
+		t.Errorf("Get(alice) after reopen = %s, want 100", got)
+	}
+}
@@ -1,39 +1,25 @@
 package merkletree
 
 import (
-	"crypto/sha256"
+	"bytes"
 	"fmt"
 )
 
-// Verifies a Merkle proof against a known root.
+// Verifies a Merkle proof against a known root using hasher to recombine
+// node hashes along the path.
 // Checks the direction to combine the hashes correctly
-func VerifyProof(root string, proof MerkleProof) bool {
+func VerifyProof(root []byte, proof MerkleProof, hasher Hasher) bool {
     currentHash := proof.hElement
 
     for i, siblingHash := range proof.siblings {
         if proof.directions[i] {
-            currentHash = hashNode(siblingHash, currentHash)
+            currentHash = hasher.HashNode(siblingHash, currentHash)
         } else {
-            currentHash = hashNode(currentHash, siblingHash)
+            currentHash = hasher.HashNode(currentHash, siblingHash)
         }
     }
 
-    return currentHash == root
-}
-
-// Hash function to hash the leaves of the merkle tree
-func hashLeaf(leaf string) string {
-	h := sha256.New()
-	h.Write([]byte(leaf))
-	return fmt.Sprintf("%x", h.Sum(nil))
-}
-
-// Hash function to be used for the construction of the merkle tree nodes
-func hashNode(a string, b string) string {
-	h := sha256.New()
-	h.Write([]byte(a))
-	h.Write([]byte(b))
-	return fmt.Sprintf("%x", h.Sum(nil))
+    return bytes.Equal(currentHash, root)
 }
 
 // Generates a Merkle proof of the inclusion of the element at the given index.
@@ -56,7 +42,7 @@ func (t *MerkleTree) GetProof(index uint64) (MerkleProof, error) {
     if int(index) >= len(t.leaves) {
         return MerkleProof{}, fmt.Errorf("index out of bounds")
     }
-    var siblings []string
+    var siblings [][]byte
     var directions []bool
     node := t.leaves[index]
     for node != t.root {
@@ -78,33 +64,46 @@ func (t *MerkleTree) GetProof(index uint64) (MerkleProof, error) {
     }, nil
 }
 
-// Creates a merkle tree from a list of elements.
-// The tree should have the minimum height needed to contain all elements.
-// Empty slots should be filled with an empty string.
-func NewMerkleTree(elements []string) (*MerkleTree, error) {
+// Creates a merkle tree from a list of elements, hashed with the given
+// Hasher. A nil hasher defaults to Sha256Hasher, preserving the tree's
+// original behavior. A nil opts also preserves the original behavior:
+// the tree should have the minimum height needed to contain all
+// elements, with empty slots filled with an empty string. Pass
+// &NewMerkleTreeOpts{Padding: NoPadding} to build a minimal-height tree
+// without empty-leaf padding instead.
+func NewMerkleTree(elements []string, hasher Hasher, opts *NewMerkleTreeOpts) (*MerkleTree, error) {
     if len(elements) == 0 {
         return nil, fmt.Errorf("no elements to create a Merkle Tree")
     }
+    if hasher == nil {
+        hasher = Sha256Hasher{}
+    }
+    if opts == nil {
+        opts = &NewMerkleTreeOpts{}
+    }
 
     leaves := make([]*Node, len(elements))
-
     for i, elem := range elements {
-        leaves[i] = &Node{hash: hashLeaf(elem)}
+        leaves[i] = &Node{hash: hasher.HashLeaf([]byte(elem))}
+    }
+
+    if opts.Padding == NoPadding {
+        return newMinimalHeightMerkleTree(leaves, hasher)
     }
 
     requiredLeaves := getNextPowerOfTwo(len(elements))
     for len(leaves) < requiredLeaves {
-        leaves = append(leaves, &Node{hash: hashLeaf("")})
+        leaves = append(leaves, &Node{hash: hasher.HashLeaf([]byte(""))})
     }
 
 	// make some memory on the heap for the nodes
-    allNodes := make([]*Node, 0, len(leaves)*2) 
+    allNodes := make([]*Node, 0, len(leaves)*2)
 
 	// put the leaves and nodes in the slots
     allNodes = append(allNodes, leaves...)
 
     for len(leaves) > 1 {
-        
+
 		var nextLevel []*Node
         for i := 0; i < len(leaves); i += 2 {
             left := leaves[i]
@@ -112,7 +111,7 @@ func NewMerkleTree(elements []string) (*MerkleTree, error) {
             parent := &Node{
                 left:  left,
                 right: right,
-                hash:  hashNode(left.hash, right.hash),
+                hash:  hasher.HashNode(left.hash, right.hash),
             }
             left.parent = parent
             right.parent = parent
@@ -122,7 +121,43 @@ func NewMerkleTree(elements []string) (*MerkleTree, error) {
         leaves = nextLevel
     }
 
-    return &MerkleTree{root: leaves[0], leaves: allNodes[:requiredLeaves]}, nil
+    return &MerkleTree{root: leaves[0], leaves: allNodes[:requiredLeaves], hasher: hasher}, nil
+}
+
+// newMinimalHeightMerkleTree builds a tree over exactly len(leaves)
+// leaves with no padding: at each layer, a trailing node without a
+// sibling is promoted to the next layer unchanged, so it keeps its
+// existing parent pointer until it's eventually paired (or becomes the
+// root directly). GetProof and UpdateElement need no special handling
+// for this, since they simply follow parent pointers to the root and a
+// promoted node's proof step is skipped because it was never paired.
+func newMinimalHeightMerkleTree(leaves []*Node, hasher Hasher) (*MerkleTree, error) {
+    allLeaves := make([]*Node, len(leaves))
+    copy(allLeaves, leaves)
+
+    level := leaves
+    for len(level) > 1 {
+        var nextLevel []*Node
+        for i := 0; i < len(level); i += 2 {
+            if i+1 == len(level) {
+                nextLevel = append(nextLevel, level[i])
+                continue
+            }
+            left := level[i]
+            right := level[i+1]
+            parent := &Node{
+                left:  left,
+                right: right,
+                hash:  hasher.HashNode(left.hash, right.hash),
+            }
+            left.parent = parent
+            right.parent = parent
+            nextLevel = append(nextLevel, parent)
+        }
+        level = nextLevel
+    }
+
+    return &MerkleTree{root: level[0], leaves: allLeaves, hasher: hasher, minimalHeight: true}, nil
 }
 
 func (n *Node) getSibling() *Node {
@@ -152,16 +187,16 @@ func (t *MerkleTree) UpdateElement(index uint64, element string) error {
     }
 
     // Update the leaf node
-    t.leaves[index].hash = hashLeaf(element)
+    t.leaves[index].hash = t.hasher.HashLeaf([]byte(element))
 
     // Update all ancestor hashes up to the root
     node := t.leaves[index]
     for node != t.root {
         parent := node.parent
         if parent.left == node {
-            parent.hash = hashNode(node.hash, parent.right.hash)
+            parent.hash = t.hasher.HashNode(node.hash, parent.right.hash)
         } else {
-            parent.hash = hashNode(parent.left.hash, node.hash)
+            parent.hash = t.hasher.HashNode(parent.left.hash, node.hash)
         }
         node = parent
     }
@@ -169,70 +204,138 @@ func (t *MerkleTree) UpdateElement(index uint64, element string) error {
     return nil
 }
 
-// ** BONUS (optional - hard) **
-// Generates a Merkle proof of the inclusion of contiguous elements,
-// starting at startIndex (inclusive) and ending at endIndex (exclusive).
-// If the indexes are out of bounds or startIndex >= endIndex, an error is returned.
-//
-// Note: modify the method signature to return your proof type.
-// Implement a separate VerifyAggregatedProof for this type.
+// nodeAt returns the node at the given layer (0 = leaves) covering the
+// subtree starting at index * 2^layer. It relies on the tree being a
+// full binary tree over a power-of-two number of leaves, so every leaf
+// sits at the same depth and can reach layer L by walking up L parents.
+func (t *MerkleTree) nodeAt(layer int, index uint64) *Node {
+    node := t.leaves[index<<uint(layer)]
+    for i := 0; i < layer; i++ {
+        node = node.parent
+    }
+    return node
+}
+
+// Generates a compressed Merkle multi-proof for the contiguous range of
+// elements [startIndex, endIndex). Unlike calling GetProof for every
+// index, siblings that are themselves part of the proved range are never
+// included: whenever two sibling nodes at a layer are both already known
+// (either because they were proved directly or because they were derived
+// from a lower layer), they are combined locally and only the resulting
+// parent is carried forward. Only hashes the verifier could not otherwise
+// derive are emitted into the proof's siblings slice.
 //
-// The aggregated proof size should generally be smaller than
-// that of the naive approach (calling GetProof for every index).
+// If the indexes are out of bounds or startIndex >= endIndex, an error is
+// returned.
 func (t *MerkleTree) GetAggregatedProof(startIndex, endIndex uint64) (*AggregatedMerkleProof, error) {
+    if t.minimalHeight {
+        return nil, fmt.Errorf("aggregated proofs are not supported for minimal-height (NoPadding) trees")
+    }
     if startIndex >= endIndex || int(endIndex) > len(t.leaves) {
-		return nil, fmt.Errorf("index out of bounds")
-	}
-
-	if endIndex - startIndex < 1 {
-		return nil, fmt.Errorf("an aggregated proof must contain at least two elements")
-	}
-
-	// Collect path from leaf to root
-	var siblings []string
-	var directions []bool
-	node := t.leaves[startIndex]
-	for node != t.root {
-		parent := node.parent
-		if parent.left == node {
-			siblings = append(siblings, parent.right.hash)
-			directions = append(directions, false)
-		} else {
-			siblings = append(siblings, parent.left.hash)
-			directions = append(directions, true)
-		}
-		node = parent
-	}
-
-	return &AggregatedMerkleProof{
-		start:      startIndex,
-		end:        endIndex,
-		siblings:   siblings,
-		directions: directions,
-	}, nil
+        return nil, fmt.Errorf("index out of bounds")
+    }
+
+    numLeaves := uint64(len(t.leaves))
+    leafHashes := make([][]byte, 0, endIndex-startIndex)
+    known := make(map[uint64][]byte, endIndex-startIndex)
+    for i := startIndex; i < endIndex; i++ {
+        leafHashes = append(leafHashes, t.leaves[i].hash)
+        known[i] = t.leaves[i].hash
+    }
+
+    var siblings [][]byte
+    for layer := 0; numLeaves>>uint(layer) > 1; layer++ {
+        next := make(map[uint64][]byte)
+        processed := make(map[uint64]bool, len(known))
+        for _, idx := range sortedUint64Keys(known) {
+            if processed[idx] {
+                continue
+            }
+            processed[idx] = true
+            sibling := idx ^ 1
+            parentIdx := idx / 2
+
+            siblingHash, haveSibling := known[sibling]
+            if haveSibling {
+                processed[sibling] = true
+            } else {
+                siblingHash = t.nodeAt(layer, sibling).hash
+                siblings = append(siblings, siblingHash)
+            }
+
+            if idx%2 == 0 {
+                next[parentIdx] = t.hasher.HashNode(known[idx], siblingHash)
+            } else {
+                next[parentIdx] = t.hasher.HashNode(siblingHash, known[idx])
+            }
+        }
+        known = next
+    }
+
+    return &AggregatedMerkleProof{
+        start:      startIndex,
+        end:        endIndex,
+        numLeaves:  numLeaves,
+        leafHashes: leafHashes,
+        siblings:   siblings,
+    }, nil
 }
 
-// Verifies an aggregated Merkle proof against a known root.
-func VerifyAggregatedProof(root string, aggProof *AggregatedMerkleProof, tree *MerkleTree) bool {
-    if aggProof.start >= aggProof.end || aggProof.end > uint64(len(tree.leaves)) {
+// Verifies an aggregated Merkle proof against a known root, knowing only
+// the root, a hasher and the claimed leaf hashes for the proved range —
+// it does not need access to the tree itself. It replays the same
+// layer-by-layer combination GetAggregatedProof used to build the proof,
+// consuming proof.siblings in order wherever a sibling isn't already
+// implied by the claimed leaf hashes.
+func VerifyAggregatedProof(root []byte, proof *AggregatedMerkleProof, hasher Hasher) bool {
+    if proof == nil || proof.start >= proof.end || proof.end > proof.numLeaves {
+        return false
+    }
+    if uint64(len(proof.leafHashes)) != proof.end-proof.start {
         return false
     }
 
-    currentHash := tree.leaves[aggProof.start].hash
+    known := make(map[uint64][]byte, len(proof.leafHashes))
+    for i, h := range proof.leafHashes {
+        known[proof.start+uint64(i)] = h
+    }
 
-    for i := 0; i < len(aggProof.siblings); i++ {
-        siblingHash := aggProof.siblings[i]
-        // Check the direction to combine the hashes correctly
-        if aggProof.directions[i] {
-            // Sibling is on the left
-            currentHash = hashNode(siblingHash, currentHash)
-        } else {
-            // Sibling is on the right
-            currentHash = hashNode(currentHash, siblingHash)
+    siblingIdx := 0
+    for layer := 0; proof.numLeaves>>uint(layer) > 1; layer++ {
+        next := make(map[uint64][]byte)
+        processed := make(map[uint64]bool, len(known))
+        for _, idx := range sortedUint64Keys(known) {
+            if processed[idx] {
+                continue
+            }
+            processed[idx] = true
+            sibling := idx ^ 1
+            parentIdx := idx / 2
+
+            siblingHash, haveSibling := known[sibling]
+            if haveSibling {
+                processed[sibling] = true
+            } else {
+                if siblingIdx >= len(proof.siblings) {
+                    return false
+                }
+                siblingHash = proof.siblings[siblingIdx]
+                siblingIdx++
+            }
+
+            if idx%2 == 0 {
+                next[parentIdx] = hasher.HashNode(known[idx], siblingHash)
+            } else {
+                next[parentIdx] = hasher.HashNode(siblingHash, known[idx])
+            }
         }
+        known = next
     }
 
-    // Compare the computed hash with the root hash
-	
-    return currentHash == root
-}
\ No newline at end of file
+    if siblingIdx != len(proof.siblings) {
+        return false
+    }
+
+    finalHash, ok := known[0]
+    return ok && bytes.Equal(finalHash, root)
+}
@@ -1,6 +1,7 @@
 package merkletree
 
 import (
+	"bytes"
 	"fmt"
 	"testing"
 )
@@ -8,7 +9,7 @@ import (
 //Happy path test for TestAggregatedProof
 func TestAggregatedProof(t *testing.T) {
     elements := []string{"some", "test", "elements", "for", "testing", "aggregated", "proofs"}
-    mt, err := NewMerkleTree(elements)
+    mt, err := NewMerkleTree(elements, Sha256Hasher{}, nil)
     if err != nil {
         t.Error(err)
     }
@@ -18,16 +19,16 @@ func TestAggregatedProof(t *testing.T) {
         t.Error(err)
     }
 
-    if !VerifyAggregatedProof(mt.getRoot(), proof, mt) {
+    if !VerifyAggregatedProof(mt.getRoot(), proof, Sha256Hasher{}) {
         t.Error("invalid proof")
     }
-	
+
 }
 
 //Test the entire merkle tree as an aggregated list of nodes to proove (edge case)
 func TestEntireMerkleTreeUsingAggregatedProof(t *testing.T) {
     elements := []string{"some", "test", "elements", "for", "testing", "aggregated", "proofs"}
-    mt, err := NewMerkleTree(elements)
+    mt, err := NewMerkleTree(elements, Sha256Hasher{}, nil)
     if err != nil {
         t.Error(err)
     }
@@ -39,18 +40,18 @@ func TestEntireMerkleTreeUsingAggregatedProof(t *testing.T) {
         t.Error(err)
     }
 
-    if !VerifyAggregatedProof(mt.getRoot(), proof, mt) {
+    if !VerifyAggregatedProof(mt.getRoot(), proof, Sha256Hasher{}) {
         t.Error("invalid proof")
     }
-	
+
 }
 
 //Test the smallest aggregated proof possible (edge case)
 //I'm being opinionated here and saying that 2 elements is the smallest aggregated proof possible
-//because 1 element is just a proof so you would use the other function for that 
+//because 1 element is just a proof so you would use the other function for that
 func TestSmallestAggregatedProof(t *testing.T) {
 	elements := []string{"one", "two"}
-	mt, err := NewMerkleTree(elements)
+	mt, err := NewMerkleTree(elements, Sha256Hasher{}, nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -60,14 +61,14 @@ func TestSmallestAggregatedProof(t *testing.T) {
 		t.Error(err)
 	}
 
-	if !VerifyAggregatedProof(mt.getRoot(), proof, mt) {
+	if !VerifyAggregatedProof(mt.getRoot(), proof, Sha256Hasher{}) {
 		t.Error("invalid proof")
 	}
 }
 
 func TestInvalidlySmallSetOfElementsAggregatedProof(t *testing.T) {
 	elements := []string{"one"}
-	mt, err := NewMerkleTree(elements)
+	mt, err := NewMerkleTree(elements, Sha256Hasher{}, nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -77,12 +78,12 @@ func TestInvalidlySmallSetOfElementsAggregatedProof(t *testing.T) {
 		t.Error("Expected an error for invalidly small set of elements, but got none")
 	}
 }
-	
+
 
 //Start index is greater than end index
 func TestInvalidAggregatedProofIndexes(t *testing.T) {
     elements := []string{"some", "test", "elements", "for", "testing", "aggregated", "proofs"}
-    mt, err := NewMerkleTree(elements)
+    mt, err := NewMerkleTree(elements, Sha256Hasher{}, nil)
     if err != nil {
         t.Error(err)
     }
@@ -97,7 +98,7 @@ func TestInvalidAggregatedProofIndexes(t *testing.T) {
 //End index is greater than the number of elements
 func TestInvalidAggregatedProofEndIndex(t *testing.T) {
     elements := []string{"some", "test", "elements", "for", "testing", "aggregated", "proofs"}
-    mt, err := NewMerkleTree(elements)
+    mt, err := NewMerkleTree(elements, Sha256Hasher{}, nil)
     if err != nil {
         t.Error(err)
     }
@@ -111,25 +112,27 @@ func TestInvalidAggregatedProofEndIndex(t *testing.T) {
 
 func TestRoot(t *testing.T) {
 	elements := []string{"some", "test", "elements"}
-	expectedRoot := hashNode(
-		hashNode(hashLeaf("some"), hashLeaf("test")),
-		hashNode(hashLeaf("elements"), hashLeaf("")),
+	hasher := Sha256Hasher{}
+	expectedRoot := hasher.HashNode(
+		hasher.HashNode(hasher.HashLeaf([]byte("some")), hasher.HashLeaf([]byte("test"))),
+		hasher.HashNode(hasher.HashLeaf([]byte("elements")), hasher.HashLeaf([]byte(""))),
 	)
 	testname := fmt.Sprintf("computes correct root")
 	t.Run(testname, func(t *testing.T) {
-		mt, err := NewMerkleTree(elements)
+		mt, err := NewMerkleTree(elements, hasher, nil)
 		if err != nil {
 			t.Error(err)
 		}
-		if mt.getRoot() != expectedRoot {
-			t.Errorf("got %s, want %s", mt.getRoot(), expectedRoot)
+		if !bytes.Equal(mt.getRoot(), expectedRoot) {
+			t.Errorf("got %x, want %x", mt.getRoot(), expectedRoot)
 		}
 	})
 }
 
 func TestProof(t *testing.T) {
 	elements := []string{"some", "test", "elements"}
-	mt, err := NewMerkleTree(elements)
+	hasher := Sha256Hasher{}
+	mt, err := NewMerkleTree(elements, hasher, nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -141,11 +144,11 @@ func TestProof(t *testing.T) {
 			if err != nil {
 				t.Error(err)
 			}
-			if !VerifyProof(mt.getRoot(), proof) {
+			if !VerifyProof(mt.getRoot(), proof, hasher) {
 				t.Error("invalid proof")
 			}
-			if hashLeaf(elem) != proof.hElement {
-				t.Errorf("got %s, want %s", elem, proof.hElement)
+			if !bytes.Equal(hasher.HashLeaf([]byte(elem)), proof.hElement) {
+				t.Errorf("got %x, want %x", proof.hElement, hasher.HashLeaf([]byte(elem)))
 			}
 		})
 	}
@@ -153,7 +156,8 @@ func TestProof(t *testing.T) {
 
 func TestProofOnSingleElement(t *testing.T) {
 	elements := []string{"one"}
-	mt, err := NewMerkleTree(elements)
+	hasher := Sha256Hasher{}
+	mt, err := NewMerkleTree(elements, hasher, nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -165,11 +169,11 @@ func TestProofOnSingleElement(t *testing.T) {
 			if err != nil {
 				t.Error(err)
 			}
-			if !VerifyProof(mt.getRoot(), proof) {
+			if !VerifyProof(mt.getRoot(), proof, hasher) {
 				t.Error("invalid proof")
 			}
-			if hashLeaf(elem) != proof.hElement {
-				t.Errorf("got %s, want %s", elem, proof.hElement)
+			if !bytes.Equal(hasher.HashLeaf([]byte(elem)), proof.hElement) {
+				t.Errorf("got %x, want %x", proof.hElement, hasher.HashLeaf([]byte(elem)))
 			}
 		})
 	}
@@ -177,7 +181,7 @@ func TestProofOnSingleElement(t *testing.T) {
 
 func TestInvalidProof(t *testing.T) {
 	elements := []string{"some", "test", "elements"}
-	mt, err := NewMerkleTree(elements)
+	mt, err := NewMerkleTree(elements, Sha256Hasher{}, nil)
 	if err != nil {
 		t.Error(err)
 	}
@@ -191,7 +195,8 @@ func TestInvalidProof(t *testing.T) {
 
 func TestUpdateElement(t *testing.T) {
     elements := []string{"alpha", "beta", "gamma"}
-    mt, _ := NewMerkleTree(elements)
+    hasher := Sha256Hasher{}
+    mt, _ := NewMerkleTree(elements, hasher, nil)
 
     // Update a leaf
     newElement := "delta"
@@ -202,8 +207,180 @@ func TestUpdateElement(t *testing.T) {
 
     // Verify the update
     updatedProof, _ := mt.GetProof(1)
-    if updatedProof.hElement != hashLeaf(newElement) {
-        t.Errorf("UpdateElement failed, got %s, want %s", updatedProof.hElement, hashLeaf(newElement))
+    if !bytes.Equal(updatedProof.hElement, hasher.HashLeaf([]byte(newElement))) {
+        t.Errorf("UpdateElement failed, got %x, want %x", updatedProof.hElement, hasher.HashLeaf([]byte(newElement)))
     }
 }
 
+// The compressed multi-proof for a non-trivial range should carry fewer
+// hashes than the naive approach of proving every index independently.
+func TestAggregatedProofSmallerThanIndependentProofs(t *testing.T) {
+	elements := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	mt, err := NewMerkleTree(elements, Sha256Hasher{}, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	proof, err := mt.GetAggregatedProof(1, 6)
+	if err != nil {
+		t.Error(err)
+	}
+	if !VerifyAggregatedProof(mt.getRoot(), proof, Sha256Hasher{}) {
+		t.Error("invalid proof")
+	}
+
+	aggregatedSize := len(proof.siblings)
+
+	var independentSize int
+	for i := uint64(1); i < 6; i++ {
+		p, err := mt.GetProof(i)
+		if err != nil {
+			t.Error(err)
+		}
+		independentSize += len(p.siblings)
+	}
+
+	if aggregatedSize >= independentSize {
+		t.Errorf("aggregated proof (%d hashes) is not smaller than independent proofs (%d hashes)", aggregatedSize, independentSize)
+	}
+}
+
+// Tampering with any claimed leaf hash must invalidate the proof.
+func TestAggregatedProofTamperedLeafFails(t *testing.T) {
+	elements := []string{"some", "test", "elements", "for", "testing", "aggregated", "proofs"}
+	hasher := Sha256Hasher{}
+	mt, err := NewMerkleTree(elements, hasher, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	proof, err := mt.GetAggregatedProof(1, 4)
+	if err != nil {
+		t.Error(err)
+	}
+
+	proof.leafHashes[0] = hasher.HashLeaf([]byte("tampered"))
+	if VerifyAggregatedProof(mt.getRoot(), proof, hasher) {
+		t.Error("expected tampered leaf hash to invalidate the proof")
+	}
+}
+
+// Tampering with any sibling hash must invalidate the proof.
+func TestAggregatedProofTamperedSiblingFails(t *testing.T) {
+	elements := []string{"some", "test", "elements", "for", "testing", "aggregated", "proofs"}
+	hasher := Sha256Hasher{}
+	mt, err := NewMerkleTree(elements, hasher, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	proof, err := mt.GetAggregatedProof(1, 4)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(proof.siblings) == 0 {
+		t.Fatal("expected at least one sibling hash for this range")
+	}
+
+	proof.siblings[0] = hasher.HashLeaf([]byte("tampered"))
+	if VerifyAggregatedProof(mt.getRoot(), proof, hasher) {
+		t.Error("expected tampered sibling hash to invalidate the proof")
+	}
+}
+
+// RFC6962Hasher's domain-separated leaf/node hashes should produce a
+// different (and still internally consistent) root than Sha256Hasher for
+// the same input elements.
+func TestRFC6962HasherDomainSeparation(t *testing.T) {
+	elements := []string{"some", "test", "elements"}
+
+	plain, err := NewMerkleTree(elements, Sha256Hasher{}, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	ct, err := NewMerkleTree(elements, RFC6962Hasher{}, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if bytes.Equal(plain.getRoot(), ct.getRoot()) {
+		t.Error("expected RFC6962Hasher root to differ from Sha256Hasher root")
+	}
+
+	proof, err := ct.GetProof(0)
+	if err != nil {
+		t.Error(err)
+	}
+	if !VerifyProof(ct.getRoot(), proof, RFC6962Hasher{}) {
+		t.Error("invalid proof under RFC6962Hasher")
+	}
+}
+
+func elementsOfSize(n int) []string {
+	elements := make([]string, n)
+	for i := 0; i < n; i++ {
+		elements[i] = fmt.Sprintf("element-%d", i)
+	}
+	return elements
+}
+
+// TestNoPaddingShorterProofsThanPadded checks, for a handful of
+// non-power-of-two leaf counts, that every leaf's NoPadding proof still
+// verifies and is never longer than its padded counterpart, and is
+// strictly shorter for at least one leaf (the ones that would otherwise
+// have been paired with an empty-leaf padding node).
+func TestNoPaddingShorterProofsThanPadded(t *testing.T) {
+    for _, n := range []int{3, 5, 6, 7, 9} {
+        elements := elementsOfSize(n)
+
+        padded, err := NewMerkleTree(elements, Sha256Hasher{}, nil)
+        if err != nil {
+            t.Fatal(err)
+        }
+        minimal, err := NewMerkleTree(elements, Sha256Hasher{}, &NewMerkleTreeOpts{Padding: NoPadding})
+        if err != nil {
+            t.Fatal(err)
+        }
+
+        sawShorter := false
+        for i := 0; i < n; i++ {
+            paddedProof, err := padded.GetProof(uint64(i))
+            if err != nil {
+                t.Fatal(err)
+            }
+            minimalProof, err := minimal.GetProof(uint64(i))
+            if err != nil {
+                t.Fatal(err)
+            }
+
+            if !VerifyProof(minimal.getRoot(), minimalProof, Sha256Hasher{}) {
+                t.Errorf("n=%d: invalid NoPadding proof for leaf %d", n, i)
+            }
+            if len(minimalProof.siblings) > len(paddedProof.siblings) {
+                t.Errorf("n=%d: NoPadding proof for leaf %d longer than padded proof (%d > %d)", n, i, len(minimalProof.siblings), len(paddedProof.siblings))
+            }
+            if len(minimalProof.siblings) < len(paddedProof.siblings) {
+                sawShorter = true
+            }
+        }
+
+        if !sawShorter {
+            t.Errorf("n=%d: expected at least one NoPadding proof strictly shorter than its padded counterpart", n)
+        }
+    }
+}
+
+// TestNoPaddingRejectsAggregatedProof documents that GetAggregatedProof's
+// layer-indexed traversal assumes a full, uniform-depth tree, so it
+// refuses to run against a minimal-height (NoPadding) tree rather than
+// silently producing a wrong or crashing result.
+func TestNoPaddingRejectsAggregatedProof(t *testing.T) {
+    mt, err := NewMerkleTree(elementsOfSize(5), Sha256Hasher{}, &NewMerkleTreeOpts{Padding: NoPadding})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    if _, err := mt.GetAggregatedProof(0, 2); err == nil {
+        t.Error("expected an error requesting an aggregated proof from a NoPadding tree")
+    }
+}
@@ -0,0 +1,26 @@
+package merkletree
+
+// PaddingMode controls how NewMerkleTree handles an element count that
+// isn't already a power of two.
+type PaddingMode int
+
+const (
+	// PadWithEmptyLeaf pads up to the next power of two with
+	// hasher.HashLeaf([]byte("")) leaves. This is the tree's original behavior.
+	PadWithEmptyLeaf PaddingMode = iota
+
+	// NoPadding builds the minimal-height tree for the given elements
+	// instead: at each layer, a trailing node without a sibling is
+	// promoted to the next layer unchanged rather than paired with an
+	// empty-leaf hash. This keeps proofs as short as possible and closes
+	// off the preimage attack where an empty-leaf padded slot lets an
+	// attacker "prove" membership of "". Matches the Tendermint "simple
+	// tree" construction.
+	NoPadding
+)
+
+// NewMerkleTreeOpts configures NewMerkleTree. The zero value
+// (PadWithEmptyLeaf) preserves the tree's original behavior.
+type NewMerkleTreeOpts struct {
+	Padding PaddingMode
+}
@@ -0,0 +1,133 @@
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// SimpleMap builds a deterministic Merkle root over a set of key/value
+// entries: Set can be called in any order, but the root only depends on
+// the final (key, value) pairs, never on insertion order. Each entry
+// becomes a leaf hash of H(H(key) || H(value)), entries are sorted
+// lexicographically by H(key), and NewMerkleTree builds the tree over
+// those leaf hashes. This suits committing to sets of named attributes
+// (block metadata, validator sets, ...) where order must not matter.
+type SimpleMap struct {
+	hasher  Hasher
+	entries map[string][]byte // H(key) (as a string) -> value
+	keys    map[string][]byte // H(key) (as a string) -> original key
+}
+
+// NewSimpleMap creates an empty SimpleMap. A nil hasher defaults to
+// Sha256Hasher.
+func NewSimpleMap(hasher Hasher) *SimpleMap {
+	if hasher == nil {
+		hasher = Sha256Hasher{}
+	}
+	return &SimpleMap{
+		hasher:  hasher,
+		entries: make(map[string][]byte),
+		keys:    make(map[string][]byte),
+	}
+}
+
+// Set records value under key, in any order; a later Set for the same
+// key replaces its value.
+func (m *SimpleMap) Set(key string, value []byte) {
+	hKey := m.hasher.HashLeaf([]byte(key))
+	m.entries[string(hKey)] = value
+	m.keys[string(hKey)] = []byte(key)
+}
+
+// sortedHashedKeys returns the entries' hashed keys sorted lexicographically.
+func (m *SimpleMap) sortedHashedKeys() []string {
+	hKeys := make([]string, 0, len(m.entries))
+	for hKey := range m.entries {
+		hKeys = append(hKeys, hKey)
+	}
+	sort.Strings(hKeys)
+	return hKeys
+}
+
+func (m *SimpleMap) entryLeaf(hKey string) []byte {
+	return m.hasher.HashNode([]byte(hKey), m.hasher.HashLeaf(m.entries[hKey]))
+}
+
+// buildTree constructs the underlying MerkleTree over the map's entries,
+// sorted by H(key). NewMerkleTree hashes each element as a leaf again, so
+// entries are passed through as raw strings and the tree is built with an
+// identityHasher that treats HashLeaf as a no-op, preserving the
+// entryLeaf hash exactly as computed above.
+func (m *SimpleMap) buildTree() (*MerkleTree, []string, error) {
+	if len(m.entries) == 0 {
+		return nil, nil, fmt.Errorf("no entries to build a Merkle Tree")
+	}
+
+	hKeys := m.sortedHashedKeys()
+	leaves := make([]string, len(hKeys))
+	for i, hKey := range hKeys {
+		leaves[i] = string(m.entryLeaf(hKey))
+	}
+
+	mt, err := NewMerkleTree(leaves, identityHasher{hasher: m.hasher}, nil)
+	return mt, hKeys, err
+}
+
+// Hash returns the deterministic Merkle root over the map's current entries.
+func (m *SimpleMap) Hash() ([]byte, error) {
+	mt, _, err := m.buildTree()
+	if err != nil {
+		return nil, err
+	}
+	return mt.getRoot(), nil
+}
+
+// Proof returns a MerkleProof of inclusion for key's current value.
+func (m *SimpleMap) Proof(key string) (MerkleProof, error) {
+	hKey := string(m.hasher.HashLeaf([]byte(key)))
+	if _, ok := m.entries[hKey]; !ok {
+		return MerkleProof{}, fmt.Errorf("merkletree: key not found")
+	}
+
+	mt, hKeys, err := m.buildTree()
+	if err != nil {
+		return MerkleProof{}, err
+	}
+
+	index := sort.SearchStrings(hKeys, hKey)
+	return mt.GetProof(uint64(index))
+}
+
+// VerifyMapProof checks that key maps to value under root, recomputing
+// the entry leaf hash from (key, value) before running standard proof
+// verification.
+func VerifyMapProof(root []byte, key string, value []byte, proof MerkleProof, hasher Hasher) bool {
+	if hasher == nil {
+		hasher = Sha256Hasher{}
+	}
+
+	hKey := hasher.HashLeaf([]byte(key))
+	expectedLeaf := hasher.HashNode(hKey, hasher.HashLeaf(value))
+	if !bytes.Equal(expectedLeaf, proof.hElement) {
+		return false
+	}
+
+	return VerifyProof(root, proof, hasher)
+}
+
+// identityHasher wraps a Hasher so HashLeaf is a no-op, letting
+// NewMerkleTree build a tree directly over pre-hashed leaves (SimpleMap's
+// entry hashes) while still combining internal nodes with the real
+// hasher.
+type identityHasher struct {
+	hasher Hasher
+}
+
+func (h identityHasher) HashLeaf(data []byte) []byte {
+	return data
+}
+
+func (h identityHasher) HashNode(left, right []byte) []byte {
+	return h.hasher.HashNode(left, right)
+}
@@ -0,0 +1,84 @@
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSimpleMapHashIsOrderIndependent(t *testing.T) {
+	a := NewSimpleMap(Sha256Hasher{})
+	a.Set("alice", []byte("100"))
+	a.Set("bob", []byte("200"))
+	a.Set("carol", []byte("300"))
+
+	b := NewSimpleMap(Sha256Hasher{})
+	b.Set("carol", []byte("300"))
+	b.Set("alice", []byte("100"))
+	b.Set("bob", []byte("200"))
+
+	rootA, err := a.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootB, err := b.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(rootA, rootB) {
+		t.Error("expected SimpleMap root to be independent of Set order")
+	}
+}
+
+func TestSimpleMapProofVerification(t *testing.T) {
+	m := NewSimpleMap(Sha256Hasher{})
+	m.Set("alice", []byte("100"))
+	m.Set("bob", []byte("200"))
+	m.Set("carol", []byte("300"))
+
+	root, err := m.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proof, err := m.Proof("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyMapProof(root, "bob", []byte("200"), proof, Sha256Hasher{}) {
+		t.Error("expected valid map proof to verify")
+	}
+	if VerifyMapProof(root, "bob", []byte("wrong"), proof, Sha256Hasher{}) {
+		t.Error("expected map proof to fail against a tampered value")
+	}
+	if VerifyMapProof(root, "mallory", []byte("200"), proof, Sha256Hasher{}) {
+		t.Error("expected map proof to fail against a different key")
+	}
+}
+
+func TestSimpleMapProofUnknownKey(t *testing.T) {
+	m := NewSimpleMap(Sha256Hasher{})
+	m.Set("alice", []byte("100"))
+
+	if _, err := m.Proof("bob"); err == nil {
+		t.Error("expected an error proving a key that was never set")
+	}
+}
+
+func TestSimpleMapSetOverwritesValue(t *testing.T) {
+	m := NewSimpleMap(Sha256Hasher{})
+	m.Set("alice", []byte("100"))
+	m.Set("alice", []byte("150"))
+
+	root, err := m.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := m.Proof("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyMapProof(root, "alice", []byte("150"), proof, Sha256Hasher{}) {
+		t.Error("expected proof to reflect the latest value for an overwritten key")
+	}
+}
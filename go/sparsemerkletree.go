@@ -0,0 +1,308 @@
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// SparseMerkleTree is a persistent, key-addressed Merkle tree alongside
+// the index-addressed MerkleTree. Keys are hashed to a fixed-length bit
+// path of `depth` bits, so the tree logically has 2^depth leaves without
+// ever materializing the empty ones: every empty subtree of height h
+// hashes to a precomputed zero-hash that depends only on h. Nodes are
+// stored through a pluggable Storage, keyed by their own hash, so only
+// the nodes on paths that have actually been written are ever stored.
+type SparseMerkleTree struct {
+	storage  Storage
+	hasher   Hasher
+	depth    int
+	hashSize int      // output width, in bytes, of hasher.HashNode; used to split stored (leftHash || rightHash) node values
+	zeroHash [][]byte // zeroHash[h] = hash of an empty subtree of height h
+	root     []byte
+}
+
+// NewSparseMerkleTree creates an empty SparseMerkleTree of the given
+// depth (number of bits in a key's path) backed by storage. A nil hasher
+// defaults to Sha256Hasher.
+func NewSparseMerkleTree(storage Storage, hasher Hasher, depth int) *SparseMerkleTree {
+	if hasher == nil {
+		hasher = Sha256Hasher{}
+	}
+
+	zeroHash := make([][]byte, depth+1)
+	zeroHash[0] = hasher.HashLeaf(nil)
+	for h := 1; h <= depth; h++ {
+		zeroHash[h] = hasher.HashNode(zeroHash[h-1], zeroHash[h-1])
+	}
+
+	return &SparseMerkleTree{
+		storage:  storage,
+		hasher:   hasher,
+		depth:    depth,
+		hashSize: len(hasher.HashNode(zeroHash[0], zeroHash[0])),
+		zeroHash: zeroHash,
+		root:     zeroHash[depth],
+	}
+}
+
+// NewSparseMerkleTreeAt reopens a SparseMerkleTree backed by storage at a
+// previously-computed root, rather than starting from the empty tree. Use
+// this after a process restart when storage (e.g. LevelDBStorage) already
+// holds the nodes for a tree built in an earlier run: NewSparseMerkleTree
+// would otherwise silently report an empty tree even though every node is
+// still on disk, since it always starts from the all-zero root.
+func NewSparseMerkleTreeAt(storage Storage, hasher Hasher, depth int, root []byte) *SparseMerkleTree {
+	t := NewSparseMerkleTree(storage, hasher, depth)
+	t.root = root
+	return t
+}
+
+// Root returns the current root hash of the tree.
+func (t *SparseMerkleTree) Root() []byte {
+	return t.root
+}
+
+func (t *SparseMerkleTree) keyPath(key []byte) []byte {
+	return t.hasher.HashLeaf(key)
+}
+
+// bitAt returns the i-th bit (0 = most significant) of path.
+func bitAt(path []byte, i int) bool {
+	byteIdx := i / 8
+	bitIdx := uint(7 - i%8)
+	return (path[byteIdx]>>bitIdx)&1 == 1
+}
+
+func leafStorageKey(leafHash []byte) []byte {
+	return append([]byte("leaf:"), leafHash...)
+}
+
+// sparseLeafHash binds key into the leaf hash so that a real leaf can
+// never collide with zeroHash[0] (= hasher.HashLeaf(nil)), the sentinel
+// an empty subtree hashes to: without key in the hash, Add(key, nil)
+// would hash to exactly that sentinel and be indistinguishable from
+// "never set". The leading marker byte keeps the hashed input non-empty
+// even when both key and value are empty.
+func sparseLeafHash(hasher Hasher, key, value []byte) []byte {
+	buf := make([]byte, 0, 1+len(key)+len(value))
+	buf = append(buf, 0x00)
+	buf = append(buf, key...)
+	buf = append(buf, value...)
+	return hasher.HashLeaf(buf)
+}
+
+func (t *SparseMerkleTree) loadNode(hash []byte) (left, right []byte, err error) {
+	v, err := t.storage.Get(hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(v) != 2*t.hashSize {
+		return nil, nil, fmt.Errorf("merkletree: corrupt sparse tree node for %x", hash)
+	}
+	return v[:t.hashSize], v[t.hashSize:], nil
+}
+
+// Get returns the value stored at key, or ErrNotFound if key has never
+// been set.
+func (t *SparseMerkleTree) Get(key []byte) ([]byte, error) {
+	path := t.keyPath(key)
+	node := t.root
+
+	for height := t.depth; height > 0; height-- {
+		if bytes.Equal(node, t.zeroHash[height]) {
+			return nil, ErrNotFound
+		}
+		left, right, err := t.loadNode(node)
+		if err != nil {
+			return nil, err
+		}
+		if bitAt(path, t.depth-height) {
+			node = right
+		} else {
+			node = left
+		}
+	}
+
+	if bytes.Equal(node, t.zeroHash[0]) {
+		return nil, ErrNotFound
+	}
+	return t.storage.Get(leafStorageKey(node))
+}
+
+// set walks the path for key from the root down, recording the sibling at
+// every height, then rebuilds the path from the new leaf back up to a new
+// root, writing every new node through a single batch.
+func (t *SparseMerkleTree) set(key, value []byte) error {
+	path := t.keyPath(key)
+	leafHash := sparseLeafHash(t.hasher, key, value)
+
+	siblings := make([][]byte, t.depth) // siblings[h] = sibling recorded when descending from height h+1 to height h
+	node := t.root
+	height := t.depth
+	for height > 0 {
+		if bytes.Equal(node, t.zeroHash[height]) {
+			for h := height; h > 0; h-- {
+				siblings[h-1] = t.zeroHash[h-1]
+			}
+			break
+		}
+		left, right, err := t.loadNode(node)
+		if err != nil {
+			return err
+		}
+		bitIndex := t.depth - height
+		if bitAt(path, bitIndex) {
+			siblings[height-1] = left
+			node = right
+		} else {
+			siblings[height-1] = right
+			node = left
+		}
+		height--
+	}
+
+	if err := t.storage.Put(leafStorageKey(leafHash), value); err != nil {
+		return err
+	}
+
+	batch := t.storage.NewBatch()
+	current := leafHash
+	for h := 0; h < t.depth; h++ {
+		bitIndex := t.depth - h - 1
+		var left, right []byte
+		if bitAt(path, bitIndex) {
+			left, right = siblings[h], current
+		} else {
+			left, right = current, siblings[h]
+		}
+		parent := t.hasher.HashNode(left, right)
+		batch.Put(parent, append(append([]byte{}, left...), right...))
+		current = parent
+	}
+	if err := batch.Commit(); err != nil {
+		return err
+	}
+
+	t.root = current
+	return nil
+}
+
+// Add inserts value at key. It returns an error if key is already set;
+// use Update to change an existing key's value.
+func (t *SparseMerkleTree) Add(key, value []byte) error {
+	if _, err := t.Get(key); err == nil {
+		return fmt.Errorf("merkletree: key already exists")
+	} else if err != ErrNotFound {
+		return err
+	}
+	return t.set(key, value)
+}
+
+// Update changes the value stored at an existing key. It returns an
+// error if key has never been set; use Add to insert a new key.
+func (t *SparseMerkleTree) Update(key, value []byte) error {
+	if _, err := t.Get(key); err != nil {
+		return err
+	}
+	return t.set(key, value)
+}
+
+// SparseMerkleProof is a compact inclusion/exclusion proof for a single
+// key: rather than carrying a sibling hash for every one of the tree's
+// `depth` levels, it carries a bitmap flagging which levels have a
+// non-empty sibling and only those siblings' hashes.
+type SparseMerkleProof struct {
+	bitmap   []byte
+	siblings [][]byte
+}
+
+// GenerateProof builds a SparseMerkleProof of the path to key.
+func (t *SparseMerkleTree) GenerateProof(key []byte) (SparseMerkleProof, error) {
+	path := t.keyPath(key)
+	node := t.root
+	height := t.depth
+
+	pathSiblings := make([][]byte, t.depth) // pathSiblings[bitIndex], root to leaf order
+	for height > 0 {
+		bitIndex := t.depth - height
+		if bytes.Equal(node, t.zeroHash[height]) {
+			for h := height; h > 0; h-- {
+				pathSiblings[t.depth-h] = t.zeroHash[h-1]
+			}
+			break
+		}
+		left, right, err := t.loadNode(node)
+		if err != nil {
+			return SparseMerkleProof{}, err
+		}
+		if bitAt(path, bitIndex) {
+			pathSiblings[bitIndex] = left
+			node = right
+		} else {
+			pathSiblings[bitIndex] = right
+			node = left
+		}
+		height--
+	}
+
+	bitmap := make([]byte, (t.depth+7)/8)
+	var siblings [][]byte
+	for bitIndex, sibling := range pathSiblings {
+		siblingHeight := t.depth - bitIndex - 1
+		if !bytes.Equal(sibling, t.zeroHash[siblingHeight]) {
+			bitmap[bitIndex/8] |= 1 << uint(7-bitIndex%8)
+			siblings = append(siblings, sibling)
+		}
+	}
+
+	return SparseMerkleProof{bitmap: bitmap, siblings: siblings}, nil
+}
+
+// VerifySparseMerkleProof checks that key maps to value under root,
+// according to proof. Pass value as nil to verify a proof of
+// non-membership. hasher and depth must match the tree the proof was
+// generated from.
+func VerifySparseMerkleProof(root []byte, key, value []byte, proof SparseMerkleProof, hasher Hasher, depth int) bool {
+	if hasher == nil {
+		hasher = Sha256Hasher{}
+	}
+
+	zeroHash := make([][]byte, depth+1)
+	zeroHash[0] = hasher.HashLeaf(nil)
+	for h := 1; h <= depth; h++ {
+		zeroHash[h] = hasher.HashNode(zeroHash[h-1], zeroHash[h-1])
+	}
+
+	path := hasher.HashLeaf(key)
+
+	var current []byte
+	if value == nil {
+		current = zeroHash[0]
+	} else {
+		current = sparseLeafHash(hasher, key, value)
+	}
+
+	siblingIdx := len(proof.siblings) - 1
+	for bitIndex := depth - 1; bitIndex >= 0; bitIndex-- {
+		bitSet := proof.bitmap[bitIndex/8]&(1<<uint(7-bitIndex%8)) != 0
+
+		var sibling []byte
+		if bitSet {
+			if siblingIdx < 0 {
+				return false
+			}
+			sibling = proof.siblings[siblingIdx]
+			siblingIdx--
+		} else {
+			sibling = zeroHash[depth-bitIndex-1]
+		}
+
+		if bitAt(path, bitIndex) {
+			current = hasher.HashNode(sibling, current)
+		} else {
+			current = hasher.HashNode(current, sibling)
+		}
+	}
+
+	return siblingIdx == -1 && bytes.Equal(current, root)
+}
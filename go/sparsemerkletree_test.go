@@ -0,0 +1,191 @@
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"testing"
+)
+
+// sha512Hasher is a test-only Hasher whose output is 64 bytes wide, used
+// to exercise SparseMerkleTree's node storage with a hash size other than
+// the built-in hashers' 32 bytes.
+type sha512Hasher struct{}
+
+func (sha512Hasher) HashLeaf(data []byte) []byte {
+	h := sha512.Sum512(data)
+	return h[:]
+}
+
+func (sha512Hasher) HashNode(left, right []byte) []byte {
+	h := sha512.New()
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+func TestSparseMerkleTreeAddGetUpdate(t *testing.T) {
+	const depth = 16
+	smt := NewSparseMerkleTree(NewMemoryStorage(), Sha256Hasher{}, depth)
+
+	keys := map[string]string{
+		"alice": "100",
+		"bob":   "200",
+		"carol": "300",
+	}
+	for k, v := range keys {
+		if err := smt.Add([]byte(k), []byte(v)); err != nil {
+			t.Fatalf("Add(%s): %v", k, err)
+		}
+	}
+
+	for k, v := range keys {
+		got, err := smt.Get([]byte(k))
+		if err != nil {
+			t.Fatalf("Get(%s): %v", k, err)
+		}
+		if string(got) != v {
+			t.Errorf("Get(%s) = %s, want %s", k, got, v)
+		}
+	}
+
+	if err := smt.Add([]byte("alice"), []byte("999")); err == nil {
+		t.Error("expected Add to fail for an existing key")
+	}
+
+	if err := smt.Update([]byte("alice"), []byte("150")); err != nil {
+		t.Fatalf("Update(alice): %v", err)
+	}
+	got, err := smt.Get([]byte("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "150" {
+		t.Errorf("Get(alice) after update = %s, want 150", got)
+	}
+
+	if err := smt.Update([]byte("dave"), []byte("x")); err == nil {
+		t.Error("expected Update to fail for a key that was never set")
+	}
+
+	if _, err := smt.Get([]byte("unknown")); err != ErrNotFound {
+		t.Errorf("Get(unknown) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSparseMerkleTreeProofVerification(t *testing.T) {
+	const depth = 16
+	smt := NewSparseMerkleTree(NewMemoryStorage(), Sha256Hasher{}, depth)
+
+	if err := smt.Add([]byte("alice"), []byte("100")); err != nil {
+		t.Fatal(err)
+	}
+	if err := smt.Add([]byte("bob"), []byte("200")); err != nil {
+		t.Fatal(err)
+	}
+
+	proof, err := smt.GenerateProof([]byte("alice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifySparseMerkleProof(smt.Root(), []byte("alice"), []byte("100"), proof, Sha256Hasher{}, depth) {
+		t.Error("expected valid membership proof to verify")
+	}
+	if VerifySparseMerkleProof(smt.Root(), []byte("alice"), []byte("wrong"), proof, Sha256Hasher{}, depth) {
+		t.Error("expected proof to fail against a tampered value")
+	}
+
+	absenceProof, err := smt.GenerateProof([]byte("carol"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifySparseMerkleProof(smt.Root(), []byte("carol"), nil, absenceProof, Sha256Hasher{}, depth) {
+		t.Error("expected valid non-membership proof to verify")
+	}
+}
+
+func TestSparseMerkleTreeEmptyValueIsNotConfusedWithUnset(t *testing.T) {
+	const depth = 16
+	smt := NewSparseMerkleTree(NewMemoryStorage(), Sha256Hasher{}, depth)
+
+	if err := smt.Add([]byte("alice"), nil); err != nil {
+		t.Fatalf("Add(alice, nil): %v", err)
+	}
+
+	got, err := smt.Get([]byte("alice"))
+	if err != nil {
+		t.Fatalf("Get(alice) after Add with an empty value: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Get(alice) = %v, want empty", got)
+	}
+
+	if err := smt.Add([]byte("alice"), []byte("100")); err == nil {
+		t.Error("expected Add to fail for a key already set to an empty value")
+	}
+}
+
+func TestSparseMerkleTreeEmptyRootIsDeterministic(t *testing.T) {
+	const depth = 8
+	a := NewSparseMerkleTree(NewMemoryStorage(), Sha256Hasher{}, depth)
+	b := NewSparseMerkleTree(NewMemoryStorage(), Sha256Hasher{}, depth)
+	if !bytes.Equal(a.Root(), b.Root()) {
+		t.Error("expected two empty trees of the same depth to share a root")
+	}
+}
+
+func TestSparseMerkleTreeReopenAtRoot(t *testing.T) {
+	const depth = 16
+	storage := NewMemoryStorage()
+	smt := NewSparseMerkleTree(storage, Sha256Hasher{}, depth)
+	if err := smt.Add([]byte("alice"), []byte("100")); err != nil {
+		t.Fatal(err)
+	}
+	if err := smt.Add([]byte("bob"), []byte("200")); err != nil {
+		t.Fatal(err)
+	}
+	root := smt.Root()
+
+	reopened := NewSparseMerkleTreeAt(storage, Sha256Hasher{}, depth, root)
+	got, err := reopened.Get([]byte("alice"))
+	if err != nil {
+		t.Fatalf("Get(alice) on reopened tree: %v", err)
+	}
+	if string(got) != "100" {
+		t.Errorf("Get(alice) on reopened tree = %s, want 100", got)
+	}
+
+	if err := reopened.Add([]byte("carol"), []byte("300")); err != nil {
+		t.Fatalf("Add(carol) on reopened tree: %v", err)
+	}
+	proof, err := reopened.GenerateProof([]byte("carol"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifySparseMerkleProof(reopened.Root(), []byte("carol"), []byte("300"), proof, Sha256Hasher{}, depth) {
+		t.Error("expected proof from reopened tree to verify")
+	}
+}
+
+func TestSparseMerkleTreeWithWideHasher(t *testing.T) {
+	const depth = 16
+	smt := NewSparseMerkleTree(NewMemoryStorage(), sha512Hasher{}, depth)
+
+	if err := smt.Add([]byte("alice"), []byte("100")); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	got, err := smt.Get([]byte("alice"))
+	if err != nil {
+		t.Fatalf("Get(alice): %v", err)
+	}
+	if string(got) != "100" {
+		t.Errorf("Get(alice) = %s, want 100", got)
+	}
+
+	proof, err := smt.GenerateProof([]byte("alice"))
+	if err != nil {
+		t.Fatalf("GenerateProof: %v", err)
+	}
+	if !VerifySparseMerkleProof(smt.Root(), []byte("alice"), []byte("100"), proof, sha512Hasher{}, depth) {
+		t.Error("expected valid membership proof to verify with a 64-byte hasher")
+	}
+}
@@ -0,0 +1,88 @@
+package merkletree
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNotFound is returned by Storage.Get when a key has no value.
+var ErrNotFound = errors.New("merkletree: key not found")
+
+// Storage persists SparseMerkleTree nodes keyed by their own hash, so a
+// tree with 2^L logical leaves never has to materialize more than the
+// nodes actually written to.
+type Storage interface {
+	Get(key []byte) ([]byte, error)
+	Put(key, value []byte) error
+	NewBatch() Batch
+}
+
+// Batch groups writes so a tree update commits atomically.
+type Batch interface {
+	Put(key, value []byte)
+	Commit() error
+}
+
+// MemoryStorage is an in-memory Storage backed by a map, useful for
+// tests and short-lived trees.
+type MemoryStorage struct {
+	mu   sync.RWMutex
+	data map[string][]byte
+}
+
+// NewMemoryStorage creates an empty in-memory Storage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{data: make(map[string][]byte)}
+}
+
+func (s *MemoryStorage) Get(key []byte) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.data[string(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	out := make([]byte, len(v))
+	copy(out, v)
+	return out, nil
+}
+
+func (s *MemoryStorage) Put(key, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v := make([]byte, len(value))
+	copy(v, value)
+	s.data[string(key)] = v
+	return nil
+}
+
+func (s *MemoryStorage) NewBatch() Batch {
+	return &memoryBatch{store: s}
+}
+
+type memoryKV struct {
+	key   []byte
+	value []byte
+}
+
+type memoryBatch struct {
+	store *MemoryStorage
+	ops   []memoryKV
+}
+
+func (b *memoryBatch) Put(key, value []byte) {
+	k := make([]byte, len(key))
+	copy(k, key)
+	v := make([]byte, len(value))
+	copy(v, value)
+	b.ops = append(b.ops, memoryKV{key: k, value: v})
+}
+
+func (b *memoryBatch) Commit() error {
+	b.store.mu.Lock()
+	defer b.store.mu.Unlock()
+	for _, op := range b.ops {
+		b.store.data[string(op.key)] = op.value
+	}
+	return nil
+}
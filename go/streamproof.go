@@ -0,0 +1,111 @@
+package merkletree
+
+import (
+	"fmt"
+	"io"
+)
+
+// stackEntry is a partial subtree root produced while streaming segments
+// through BuildReaderProof. level is the subtree's height (0 = a single
+// leaf) and start is the index of the first leaf it covers.
+type stackEntry struct {
+	level uint64
+	start uint64
+	hash  []byte
+}
+
+// combine merges two adjacent subtree entries (left always preceding
+// right) into their parent, recording a sibling hash for proofIndex if it
+// falls within either entry's span.
+func combine(hasher Hasher, left, right stackEntry, proofIndex uint64, found bool, siblings *[][]byte, directions *[]bool) stackEntry {
+	if found {
+		leftSpan := uint64(1) << left.level
+		if proofIndex >= left.start && proofIndex < left.start+leftSpan {
+			*siblings = append(*siblings, right.hash)
+			*directions = append(*directions, false)
+		} else if proofIndex >= right.start {
+			*siblings = append(*siblings, left.hash)
+			*directions = append(*directions, true)
+		}
+	}
+	return stackEntry{
+		level: left.level + 1,
+		start: left.start,
+		hash:  hasher.HashNode(left.hash, right.hash),
+	}
+}
+
+// BuildReaderProof reads r in fixed-size segments, hashing each as a leaf
+// and building the tree incrementally so the whole input never has to be
+// held in memory at once. It maintains a stack of partial subtree roots
+// keyed by level: when two roots of the same level are on top of the
+// stack they are combined into a level+1 root (the classic streaming
+// Merkle algorithm). Sibling hashes along the path to proofIndex are
+// recorded as they are produced, and at EOF any remaining stack entries
+// are folded together from right to left (smallest subtree first, so the
+// largest subtree becomes the root's left child) to produce the final root,
+// matching RFC 6962's MTH definition for leaf counts that aren't a power
+// of two. A nil hasher defaults to Sha256Hasher.
+func BuildReaderProof(r io.Reader, segmentSize int, proofIndex uint64, hasher Hasher) (root []byte, proof MerkleProof, numLeaves uint64, err error) {
+	if segmentSize <= 0 {
+		return nil, MerkleProof{}, 0, fmt.Errorf("segmentSize must be positive")
+	}
+	if hasher == nil {
+		hasher = Sha256Hasher{}
+	}
+
+	var stack []stackEntry
+	var siblings [][]byte
+	var directions []bool
+	var proofHash []byte
+	found := false
+
+	buf := make([]byte, segmentSize)
+	for {
+		n, readErr := io.ReadFull(r, buf)
+		if n > 0 {
+			segment := make([]byte, n)
+			copy(segment, buf[:n])
+
+			idx := numLeaves
+			numLeaves++
+			leafHash := hasher.HashLeaf(segment)
+			if idx == proofIndex {
+				proofHash = leafHash
+				found = true
+			}
+
+			stack = append(stack, stackEntry{level: 0, start: idx, hash: leafHash})
+			for len(stack) >= 2 && stack[len(stack)-1].level == stack[len(stack)-2].level {
+				right := stack[len(stack)-1]
+				left := stack[len(stack)-2]
+				stack = stack[:len(stack)-2]
+				stack = append(stack, combine(hasher, left, right, proofIndex, found, &siblings, &directions))
+			}
+		}
+		if readErr != nil {
+			if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+				break
+			}
+			return nil, MerkleProof{}, 0, readErr
+		}
+	}
+
+	if numLeaves == 0 {
+		return nil, MerkleProof{}, 0, fmt.Errorf("no data to build a Merkle Tree")
+	}
+	if proofIndex >= numLeaves {
+		return nil, MerkleProof{}, 0, fmt.Errorf("index out of bounds")
+	}
+
+	acc := stack[len(stack)-1]
+	for i := len(stack) - 2; i >= 0; i-- {
+		acc = combine(hasher, stack[i], acc, proofIndex, found, &siblings, &directions)
+	}
+
+	return acc.hash, MerkleProof{
+		hElement:   proofHash,
+		siblings:   siblings,
+		directions: directions,
+	}, numLeaves, nil
+}
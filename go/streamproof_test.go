@@ -0,0 +1,81 @@
+package merkletree
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBuildReaderProofMatchesNewMerkleTreeForPowerOfTwoSegments(t *testing.T) {
+	segments := []string{"some", "test", "word", "here"} // already a power of two, so NewMerkleTree does not pad
+	data := strings.Join(segments, "")
+
+	mt, err := NewMerkleTree(segments, Sha256Hasher{}, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	root, _, numLeaves, err := BuildReaderProof(strings.NewReader(data), len(segments[0]), 0, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if numLeaves != uint64(len(segments)) {
+		t.Errorf("got %d leaves, want %d", numLeaves, len(segments))
+	}
+	if !bytes.Equal(root, mt.getRoot()) {
+		t.Errorf("got root %x, want %x", root, mt.getRoot())
+	}
+}
+
+func TestBuildReaderProofValidForEveryIndexNonPowerOfTwo(t *testing.T) {
+	segments := []string{"a", "b", "c", "d", "e"} // 5 segments: not a power of two
+	data := strings.Join(segments, "")
+
+	for i := range segments {
+		root, proof, numLeaves, err := BuildReaderProof(strings.NewReader(data), 1, uint64(i), nil)
+		if err != nil {
+			t.Error(err)
+		}
+		if numLeaves != uint64(len(segments)) {
+			t.Errorf("got %d leaves, want %d", numLeaves, len(segments))
+		}
+		if !VerifyProof(root, proof, Sha256Hasher{}) {
+			t.Errorf("invalid proof for index %d", i)
+		}
+	}
+}
+
+func TestBuildReaderProofLastSegmentShorterThanSegmentSize(t *testing.T) {
+	data := "abcdefg" // segmentSize 3 -> segments "abc", "def", "g"
+	root, proof, numLeaves, err := BuildReaderProof(strings.NewReader(data), 3, 2, nil)
+	if err != nil {
+		t.Error(err)
+	}
+	if numLeaves != 3 {
+		t.Errorf("got %d leaves, want 3", numLeaves)
+	}
+	if !VerifyProof(root, proof, Sha256Hasher{}) {
+		t.Error("invalid proof for trailing short segment")
+	}
+}
+
+func TestBuildReaderProofEmptyInput(t *testing.T) {
+	_, _, _, err := BuildReaderProof(strings.NewReader(""), 4, 0, nil)
+	if err == nil {
+		t.Error("expected an error for empty input, but got none")
+	}
+}
+
+func TestBuildReaderProofIndexOutOfBounds(t *testing.T) {
+	_, _, _, err := BuildReaderProof(strings.NewReader("abcd"), 1, 10, nil)
+	if err == nil {
+		t.Error("expected an error for out of bounds proofIndex, but got none")
+	}
+}
+
+func TestBuildReaderProofInvalidSegmentSize(t *testing.T) {
+	_, _, _, err := BuildReaderProof(strings.NewReader("abcd"), 0, 0, nil)
+	if err == nil {
+		t.Error("expected an error for non-positive segmentSize, but got none")
+	}
+}
@@ -1,26 +1,29 @@
 package merkletree
 
 type Node struct {
-    hash  string // hash of the node
+    hash  []byte // hash of the node
     left  *Node	 // left child
     right *Node	 // right child
 	parent *Node // parent node
 }
 
 type MerkleProof struct {
-	hElement   string   // hash of element for which we want to prove inclusion
-	siblings   []string // path of siblings from the element up to the root
+	hElement   []byte   // hash of element for which we want to prove inclusion
+	siblings   [][]byte // path of siblings from the element up to the root
 	directions []bool   // signal if this sibling at this index of the directions array is on the left or right of its parent
 }
 
 type MerkleTree struct {
-    root   *Node		// root of the tree
-    leaves []*Node		// leaves of the tree
+    root          *Node  // root of the tree
+    leaves        []*Node // leaves of the tree
+    hasher        Hasher  // hash function used to build and verify this tree
+    minimalHeight bool    // true if built with NoPadding; layer-indexed proofs (GetAggregatedProof) assume a full tree and don't support this
 }
 
 type AggregatedMerkleProof struct {
-    start       uint64  // index of the first element in the proof
-    end         uint64  // index of the last element in the proof
-    siblings    []string  // path of siblings from the element up to the root
-    directions  []bool    // signal if this sibling at this index of the directions array is on the left or right of its parent
-}
\ No newline at end of file
+    start      uint64   // index of the first element in the proof (inclusive)
+    end        uint64   // index of the last element in the proof (exclusive)
+    numLeaves  uint64   // total number of leaves in the tree the proof was generated against
+    leafHashes [][]byte // claimed leaf hashes for [start, end), in index order
+    siblings   [][]byte // sibling hashes needed to reconstruct the root, in traversal order
+}
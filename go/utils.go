@@ -1,6 +1,8 @@
 
 package merkletree
 
+import "sort"
+
 //Optimized power of 2 helper function that uses bitwise operations in log(n) time
 func getNextPowerOfTwo(n int) int {
     if n <= 0 {
@@ -15,9 +17,21 @@ func getNextPowerOfTwo(n int) int {
     return n + 1
 }
 
-func (t *MerkleTree) getRoot() string {
+func (t *MerkleTree) getRoot() []byte {
     if t.root == nil {
-        return ""
+        return nil
     }
     return t.root.hash
+}
+
+// sortedUint64Keys returns the keys of a layer of known node hashes in
+// ascending order, so multi-proof construction/verification processes
+// siblings in a deterministic, index-driven order.
+func sortedUint64Keys(m map[uint64][]byte) []uint64 {
+    keys := make([]uint64, 0, len(m))
+    for k := range m {
+        keys = append(keys, k)
+    }
+    sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+    return keys
 }
\ No newline at end of file